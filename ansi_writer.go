@@ -0,0 +1,12 @@
+package log
+
+import "io"
+
+// NewAnsiColorWriter 包一层 io.Writer：非 Windows 平台上原样透传，带颜色的
+// \033[...m 转义序列直接写穿；Windows 下优先尝试打开
+// ENABLE_VIRTUAL_TERMINAL_PROCESSING 让新版控制台原生认识 ANSI，开不了的话
+// （老版 cmd.exe）退化成解析 SGR 序列并翻译成 SetConsoleTextAttribute 调用。
+// 包装对非控制台的 Writer（文件、管道、网络连接……）始终是透传
+func NewAnsiColorWriter(w io.Writer) io.Writer {
+	return newAnsiColorWriter(w)
+}
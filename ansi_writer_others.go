@@ -0,0 +1,10 @@
+//go:build !windows
+
+package log
+
+import "io"
+
+// 非 Windows 平台的终端本来就认 ANSI 转义序列，不需要做任何翻译
+func newAnsiColorWriter(w io.Writer) io.Writer {
+	return w
+}
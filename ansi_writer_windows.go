@@ -0,0 +1,129 @@
+//go:build windows
+
+package log
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                    = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode          = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode          = kernel32.NewProc("SetConsoleMode")
+	procSetConsoleTextAttribute = kernel32.NewProc("SetConsoleTextAttribute")
+)
+
+const enableVirtualTerminalProcessing = 0x0004
+
+// defaultConsoleAttribute 是 cmd.exe 的默认配色：白字黑底
+const defaultConsoleAttribute = 7
+
+// 控制台属性里各个 bit 的含义：前景色占 0-2 位，前景高亮占第 3 位，
+// 背景色占 4-6 位，背景高亮占第 7 位
+const (
+	fgColorMask = 0x0007
+	fgIntensity = 0x0008
+	bgColorMask = 0x0070
+	bgIntensity = 0x0080
+)
+
+// ansiToWindows 把 ANSI 30-37/90-97 前景色（40-47/100-107 背景色同理）的颜色
+// 序号映射成控制台属性里的 R/G/B 三个 bit（顺序和 ANSI 不一样）
+var ansiToWindows = [8]uint16{0, 4, 2, 6, 1, 5, 3, 7}
+
+var sgrPattern = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// ansiColorWriter 是 AnsiColorWriter 在 Windows 旧版控制台下的实现：把写入
+// 内容里的 ANSI SGR 序列摘出来，翻译成 SetConsoleTextAttribute 调用，
+// 其余字节原样写给底层控制台
+type ansiColorWriter struct {
+	w    io.Writer
+	fd   syscall.Handle
+	attr uint16
+}
+
+// newAnsiColorWriter 是 NewAnsiColorWriter 在 windows 下的实现。w 不是控制台
+// 句柄（重定向到文件/管道），或者新版控制台原生支持虚拟终端时，直接透传
+func newAnsiColorWriter(w io.Writer) io.Writer {
+	f, ok := w.(*os.File)
+	if !ok {
+		return w
+	}
+
+	fd := syscall.Handle(f.Fd())
+
+	var mode uint32
+	if r, _, _ := procGetConsoleMode.Call(uintptr(fd), uintptr(unsafe.Pointer(&mode))); r == 0 {
+		return w
+	}
+
+	if r, _, _ := procSetConsoleMode.Call(uintptr(fd), uintptr(mode|enableVirtualTerminalProcessing)); r != 0 {
+		return w
+	}
+
+	return &ansiColorWriter{w: w, fd: fd, attr: defaultConsoleAttribute}
+}
+
+// Write 实现 io.Writer
+func (w *ansiColorWriter) Write(p []byte) (int, error) {
+	last := 0
+	for _, loc := range sgrPattern.FindAllSubmatchIndex(p, -1) {
+		start, end := loc[0], loc[1]
+
+		if start > last {
+			if _, err := w.w.Write(p[last:start]); err != nil {
+				return start, err
+			}
+		}
+
+		w.attr = applySGR(w.attr, string(p[loc[2]:loc[3]]))
+		procSetConsoleTextAttribute.Call(uintptr(w.fd), uintptr(w.attr))
+
+		last = end
+	}
+
+	if last < len(p) {
+		if _, err := w.w.Write(p[last:]); err != nil {
+			return last, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// applySGR 把一组用分号隔开的 SGR 参数叠加到 attr 上
+func applySGR(attr uint16, codes string) uint16 {
+	if codes == "" {
+		codes = "0"
+	}
+
+	for _, c := range strings.Split(codes, ";") {
+		n, err := strconv.Atoi(c)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case n == 0:
+			attr = defaultConsoleAttribute
+		case n == 1:
+			attr |= fgIntensity
+		case n >= 30 && n <= 37:
+			attr = attr&^fgColorMask | ansiToWindows[n-30]
+		case n >= 40 && n <= 47:
+			attr = attr&^bgColorMask | ansiToWindows[n-40]<<4
+		case n >= 90 && n <= 97:
+			attr = attr&^fgColorMask | ansiToWindows[n-90] | fgIntensity
+		case n >= 100 && n <= 107:
+			attr = attr&^bgColorMask | ansiToWindows[n-100]<<4 | bgIntensity
+		}
+	}
+
+	return attr
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package log
+
+import "testing"
+
+// TestApplySGR 覆盖 SGR -> 控制台属性的映射：重置、加粗、普通色、以及容易漏掉
+// 的高亮前景/背景（90-97/100-107），因为 middleware（chunk0-2）和
+// DefaultStackTheme（chunk0-4）都会发出这些高亮色
+func TestApplySGR(t *testing.T) {
+	t.Run("reset restores default attribute", func(t *testing.T) {
+		if got := applySGR(0x1234, "0"); got != defaultConsoleAttribute {
+			t.Fatalf("applySGR(_, \"0\") = %#x, want %#x", got, defaultConsoleAttribute)
+		}
+	})
+
+	t.Run("bold only sets foreground intensity", func(t *testing.T) {
+		got := applySGR(ansiToWindows[2], "1")
+		if got != ansiToWindows[2]|fgIntensity {
+			t.Fatalf("applySGR(green, \"1\") = %#x, want %#x", got, ansiToWindows[2]|fgIntensity)
+		}
+	})
+
+	t.Run("normal foreground color", func(t *testing.T) {
+		got := applySGR(defaultConsoleAttribute, "32")
+		if got&fgColorMask != ansiToWindows[2] {
+			t.Fatalf("fg = %#x, want %#x", got&fgColorMask, ansiToWindows[2])
+		}
+		if got&fgIntensity != 0 {
+			t.Fatalf("plain 32 should not set foreground intensity, got %#x", got)
+		}
+	})
+
+	t.Run("bright foreground sets color and intensity", func(t *testing.T) {
+		got := applySGR(defaultConsoleAttribute, "92")
+		if got&fgColorMask != ansiToWindows[2] {
+			t.Fatalf("fg = %#x, want %#x", got&fgColorMask, ansiToWindows[2])
+		}
+		if got&fgIntensity == 0 {
+			t.Fatalf("bright foreground 92 should set intensity bit, got %#x", got)
+		}
+	})
+
+	t.Run("bright background sets color and intensity", func(t *testing.T) {
+		got := applySGR(defaultConsoleAttribute, "102")
+		if (got&bgColorMask)>>4 != ansiToWindows[2] {
+			t.Fatalf("bg = %#x, want %#x", (got&bgColorMask)>>4, ansiToWindows[2])
+		}
+		if got&bgIntensity == 0 {
+			t.Fatalf("bright background 102 should set intensity bit, got %#x", got)
+		}
+	})
+
+	t.Run("combination used by the access-log middleware", func(t *testing.T) {
+		// \033[97;42m: 2xx 状态码用的白字绿底
+		got := applySGR(defaultConsoleAttribute, "97;42")
+		if got&fgColorMask != ansiToWindows[7] {
+			t.Fatalf("fg = %#x, want %#x", got&fgColorMask, ansiToWindows[7])
+		}
+		if (got&bgColorMask)>>4 != ansiToWindows[2] {
+			t.Fatalf("bg = %#x, want %#x", (got&bgColorMask)>>4, ansiToWindows[2])
+		}
+	})
+
+	t.Run("combination used by DefaultStackTheme gutter/comment", func(t *testing.T) {
+		// \033[90m: 亮黑（灰）前景，StackTheme.Gutter/Comment 用的颜色
+		got := applySGR(defaultConsoleAttribute, "90")
+		if got&fgColorMask != ansiToWindows[0] {
+			t.Fatalf("fg = %#x, want %#x", got&fgColorMask, ansiToWindows[0])
+		}
+		if got&fgIntensity == 0 {
+			t.Fatalf("90 is a bright color, should set foreground intensity, got %#x", got)
+		}
+	})
+}
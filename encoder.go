@@ -0,0 +1,94 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// Encoder 把一条 record 序列化成可以直接写入 io.Writer 的字节流，
+// 每次 Encode 返回的内容都应当以换行结尾
+type Encoder interface {
+	Encode(r *record) ([]byte, error)
+}
+
+// TextEncoder 按照 NewTextEncoder 传入的 template 渲染成一行文本，有 Stack 时
+// 原样附在后面。Standard 没有调用 SetOutputFormat 时不经过 Encoder，而是直接用
+// 自己当前的模板渲染
+type TextEncoder struct {
+	tpl *template.Template
+}
+
+// NewTextEncoder 用给定的 template 构造一个 TextEncoder
+func NewTextEncoder(tpl *template.Template) *TextEncoder {
+	return &TextEncoder{tpl: tpl}
+}
+
+// Encode 实现 Encoder
+func (e *TextEncoder) Encode(r *record) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.tpl.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	if len(r.Stack) > 0 {
+		buf.Write(r.Stack)
+	}
+	return buf.Bytes(), nil
+}
+
+// reservedJSONKeys 是 JSONEncoder 固定写出的字段名。TprintfFields 传入的
+// Fields 如果撞了同名 key，会被改名成 "fields.<key>" 再写进去，避免覆盖掉
+// time/level/msg 这几个字段本身（和 logrus 的 JSONFormatter 处理冲突字段的
+// 方式一致）
+var reservedJSONKeys = map[string]bool{
+	"time": true, "level": true, "tag": true, "file": true,
+	"line": true, "msg": true, "stack": true,
+}
+
+// JSONEncoder 把 record 编码成单行 JSON，每条日志对应一个 JSON 对象。
+// 调用方通过 TprintfFields 传入的 Fields 被打平到和 time/level/msg 同一层，
+// 不需要额外的解析规则就能直接喂给 ELK/Loki
+type JSONEncoder struct{}
+
+// NewJSONEncoder 返回一个 JSONEncoder
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+// Encode 实现 Encoder
+func (e *JSONEncoder) Encode(r *record) ([]byte, error) {
+	m := make(map[string]interface{}, len(r.Fields)+7)
+
+	if r.Date != "" || r.Time != "" {
+		m["time"] = strings.TrimSpace(r.Date + " " + r.Time)
+	}
+	m["level"] = r.Level
+	if r.Tag != "" {
+		m["tag"] = r.Tag
+	}
+	if r.File != "" {
+		m["file"] = r.File
+	}
+	if r.Line != 0 {
+		m["line"] = r.Line
+	}
+	m["msg"] = r.Message
+	if len(r.Stack) > 0 {
+		m["stack"] = string(r.Stack)
+	}
+
+	for k, v := range r.Fields {
+		if reservedJSONKeys[k] {
+			k = "fields." + k
+		}
+		m[k] = v
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,195 @@
+// Package middleware 提供基于 log.Standard 的 HTTP 访问日志中间件
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/mingshi/log"
+)
+
+// Config 是 LoggingWithConfig 的可选配置，零值等价于 Logging 的默认行为
+type Config struct {
+	Logger *log.Standard // 为空时使用 log.NewStandard(os.Stdout, log.DefaultFormat)
+	Level  log.Level     // 打到 Logger 的级别，为空时用 log.InfoLevel
+	Tag    string        // 打到 Logger 的 tag，为空时用 "http"
+}
+
+var defaultConfig = Config{
+	Level: log.InfoLevel,
+	Tag:   "http",
+}
+
+// Logging 返回一个记录访问日志的 http.Handler 中间件，使用默认配置
+func Logging(next http.Handler) http.Handler {
+	return LoggingWithConfig(defaultConfig, next)
+}
+
+// LoggingWithConfig 和 Logging 类似，但允许调用方指定 logger、级别和 tag
+func LoggingWithConfig(cfg Config, next http.Handler) http.Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.NewStandard(os.Stdout, log.DefaultFormat)
+		// 自带的默认 logger 开箱即带色；调用方传自己的 Logger 则尊重它的设置。
+		// NO_COLOR 在这里也要认，否则 calculateColor 还是会把每一行包上
+		// \x1b[...m，只是前缀/重置码被 statusColor/methodColor/resetCode 挡掉了
+		logger.Colorized(!colorsDisabled())
+	}
+	level := cfg.Level
+	if level == 0 {
+		level = log.InfoLevel
+	}
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "http"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		latency := time.Since(start)
+		ip := clientIP(r)
+		path := r.URL.Path
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+		reset := resetCode(logger)
+
+		line := fmt.Sprintf("|%s %3d %s| %13v | %15s |%s %-7s%s %s",
+			statusColor(sw.status, logger), sw.status, reset,
+			latency, ip,
+			methodColor(r.Method, logger), r.Method, reset, path)
+
+		logger.Tprintf(level, level, tag, "%s", line)
+	})
+}
+
+// statusWriter 包了一层 http.ResponseWriter，记录下游写的状态码。
+// 除了 WriteHeader，其余方法都直接转发给被包装的 ResponseWriter，
+// 这样 SSE/WebSocket 之类需要 http.Flusher/http.Hijacker/http.Pusher
+// 的 handler 被 Logging 包一层之后还能正常断言出这些接口
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush 透传给下层的 http.Flusher，支持流式/SSE 响应
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 透传给下层的 http.Hijacker，支持 WebSocket 之类接管连接的场景
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: underlying ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Push 透传给下层的 http.Pusher，支持 HTTP/2 server push
+func (w *statusWriter) Push(target string, opts *http.PushOptions) error {
+	p, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return p.Push(target, opts)
+}
+
+// clientIP 优先取 X-Forwarded-For 的第一个地址，其次取 X-Real-IP，都取不到再退回 RemoteAddr
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if i := indexByte(xff, ','); i != -1 {
+			return xff[:i]
+		}
+		return xff
+	}
+	if xrip := r.Header.Get("X-Real-IP"); xrip != "" {
+		return xrip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func indexByte(s string, b byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// colorsDisabled 和 Standard.Colorized(false) 的语义对齐：设置了 NO_COLOR
+// 时无条件关闭着色，此外也要看具体 logger 自己是否开启了着色。每次都重新读
+// 环境变量而不是缓存成包级变量，这样运行期用 os.Setenv("NO_COLOR", ...)
+// 切换（常见于测试）能立刻生效
+func colorsDisabled() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+func resetCode(l *log.Standard) string {
+	if colorsDisabled() || !l.IsColorized() {
+		return ""
+	}
+	return "\033[0m"
+}
+
+// statusColor 根据状态码返回 2xx 绿、3xx 青、4xx 黄、5xx 红的背景色转义序列
+func statusColor(code int, l *log.Standard) string {
+	if colorsDisabled() || !l.IsColorized() {
+		return ""
+	}
+	switch {
+	case code >= 200 && code < 300:
+		return "\033[97;42m"
+	case code >= 300 && code < 400:
+		return "\033[90;46m"
+	case code >= 400 && code < 500:
+		return "\033[90;43m"
+	default:
+		return "\033[97;41m"
+	}
+}
+
+// methodColor 给常见的 HTTP method 上色，未知的 method 不上色
+func methodColor(method string, l *log.Standard) string {
+	if colorsDisabled() || !l.IsColorized() {
+		return ""
+	}
+	switch method {
+	case http.MethodGet:
+		return "\033[97;44m"
+	case http.MethodPost:
+		return "\033[97;46m"
+	case http.MethodPut:
+		return "\033[97;43m"
+	case http.MethodDelete:
+		return "\033[97;41m"
+	case http.MethodPatch:
+		return "\033[97;42m"
+	case http.MethodHead:
+		return "\033[97;45m"
+	case http.MethodOptions:
+		return "\033[90;47m"
+	default:
+		return ""
+	}
+}
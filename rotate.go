@@ -0,0 +1,251 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const rotatingDateFormat = "20060102"
+
+// RotatingFileWriter 是一个按天滚动的 io.Writer，可以直接传给
+// NewStandard/Standard.SetWriter。文件名形如 prefix_20060102.log，
+// 在每天本地时间零点由后台 goroutine 自动滚动，滚动瞬间的并发 Write
+// 由 mu 这把 RWMutex 保护，不会丢字节也不会和 Standard.mu 死锁。
+type RotatingFileWriter struct {
+	mu sync.RWMutex
+
+	dir        string
+	prefix     string
+	maxBackups int       // 保留的历史文件数，0 表示不清理
+	compress   bool      // 滚动后是否把旧文件 gzip 压缩
+	tee        io.Writer // 额外镜像写一份，比如 os.Stdout；为空则不镜像
+
+	file *os.File
+	day  string // 当前打开文件对应的日期后缀
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+
+	rotateMu sync.Mutex // 串行化 finishRotation，避免前一次滚动的压缩/清理还没做完，下一次滚动又并发跑一遍
+}
+
+// NewRotatingFileWriter 在 dir 目录下创建/打开当天的日志文件，并开始按天滚动。
+// maxBackups 为 0 表示不清理历史文件；compress 为 true 时滚动产生的旧文件会
+// 被异步 gzip 压缩成 .log.gz。
+func NewRotatingFileWriter(dir, prefix string, maxBackups int, compress bool) (*RotatingFileWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	w := &RotatingFileWriter{
+		dir:        dir,
+		prefix:     prefix,
+		maxBackups: maxBackups,
+		compress:   compress,
+		stopCh:     make(chan struct{}),
+	}
+
+	if err := w.openLocked(time.Now()); err != nil {
+		return nil, err
+	}
+
+	go w.rollLoop()
+
+	return w, nil
+}
+
+// Tee 设置一份额外的镜像输出，比如 os.Stdout；传 nil 关闭镜像
+func (w *RotatingFileWriter) Tee(tee io.Writer) {
+	w.mu.Lock()
+	w.tee = tee
+	w.mu.Unlock()
+}
+
+// Close 停止后台滚动并关闭当前文件，可以安全地调用多次
+func (w *RotatingFileWriter) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.stopCh)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		err = w.file.Close()
+	})
+	return err
+}
+
+// Write 实现 io.Writer。正常情况下只持读锁写文件；一旦发现跨天，
+// 升级成写锁完成滚动后再写，保证滚动瞬间不丢字节。
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	today := time.Now().Format(rotatingDateFormat)
+
+	w.mu.RLock()
+	sameDay := today == w.day
+	if sameDay {
+		n, err := w.writeLocked(p)
+		w.mu.RUnlock()
+		return n, err
+	}
+	w.mu.RUnlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if today != w.day {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	return w.writeLocked(p)
+}
+
+// writeLocked 假设调用方已经持有读锁或写锁
+func (w *RotatingFileWriter) writeLocked(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	if w.tee != nil {
+		if _, tErr := w.tee.Write(p); tErr != nil {
+			fmt.Fprintf(os.Stderr, "log: RotatingFileWriter tee write error: %v\n", tErr)
+		}
+	}
+	return n, err
+}
+
+// openLocked 假设调用方已经持有写锁
+func (w *RotatingFileWriter) openLocked(t time.Time) error {
+	f, err := os.OpenFile(w.fileName(t), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file = f
+	w.day = t.Format(rotatingDateFormat)
+	return nil
+}
+
+// rotateLocked 关闭当天的文件，打开新一天的文件，并异步压缩/清理旧文件。
+// 假设调用方已经持有写锁。
+func (w *RotatingFileWriter) rotateLocked() error {
+	rotated := w.file.Name()
+
+	if err := w.openLocked(time.Now()); err != nil {
+		return err
+	}
+
+	if w.compress || w.maxBackups > 0 {
+		go w.finishRotation(rotated)
+	}
+
+	return nil
+}
+
+// finishRotation 在后台把刚滚动下来的文件压缩、再清理多余的历史文件。两步
+// 放在同一个 goroutine 里顺序执行，避免和另一次滚动触发的 finishRotation
+// 并发读写同一批文件名（比如 pruneBackups 在文件压缩完成前就把它数进待清理
+// 列表，或者两次压缩同时创建同名 .gz）
+func (w *RotatingFileWriter) finishRotation(rotated string) {
+	w.rotateMu.Lock()
+	defer w.rotateMu.Unlock()
+
+	if w.compress {
+		if err := compressAndRemove(rotated); err != nil {
+			fmt.Fprintf(os.Stderr, "log: RotatingFileWriter compress %s: %v\n", rotated, err)
+		}
+	}
+
+	if w.maxBackups > 0 {
+		w.pruneBackups()
+	}
+}
+
+func (w *RotatingFileWriter) fileName(t time.Time) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s_%s.log", w.prefix, t.Format(rotatingDateFormat)))
+}
+
+// rollLoop 每天本地时间零点触发一次滚动，哪怕这段时间里完全没有 Write 调用，
+// 也能保证文件名和磁盘上的"今天"对得上
+func (w *RotatingFileWriter) rollLoop() {
+	for {
+		next := nextMidnight(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+			w.mu.Lock()
+			if time.Now().Format(rotatingDateFormat) != w.day {
+				w.rotateLocked()
+			}
+			w.mu.Unlock()
+		case <-w.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func nextMidnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
+
+// compressAndRemove 把滚动下来的旧文件 gzip 压缩成 <name>.gz 并删除原文件
+func compressAndRemove(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(name + ".gz")
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// pruneBackups 只保留最近 maxBackups 份历史文件（不含当天正在写的文件），
+// 删掉更早的 .log/.log.gz
+func (w *RotatingFileWriter) pruneBackups() {
+	pattern := filepath.Join(w.dir, w.prefix+"_*")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+
+	current := filepath.Base(w.fileName(time.Now()))
+	backups := matches[:0]
+	for _, m := range matches {
+		if filepath.Base(m) == current {
+			continue
+		}
+		backups = append(backups, m)
+	}
+
+	sort.Strings(backups)
+
+	if len(backups) <= w.maxBackups {
+		return
+	}
+	for _, m := range backups[:len(backups)-w.maxBackups] {
+		os.Remove(m)
+	}
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRotatingFileWriterRollover 把 w.day 拨回一个很早的日期来模拟 rollLoop
+// 还没来得及把它刷新成"今天"的状态（午夜交界的那一刻），验证 Write 自己发现
+// 跨天、完成 RLock -> Lock 升级和滚动之后，新旧两次写入的内容都完整落盘，
+// 且滚动后 w.day 重新和当前日期对上
+func TestRotatingFileWriterRollover(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(dir, "app", 0, false)
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("line1\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	w.mu.Lock()
+	staleFile := w.file
+	w.day = "19700101"
+	w.mu.Unlock()
+
+	if _, err := w.Write([]byte("line2\n")); err != nil {
+		t.Fatalf("Write across the simulated rollover: %v", err)
+	}
+
+	w.mu.RLock()
+	gotDay := w.day
+	freshFile := w.file
+	path := w.file.Name()
+	w.mu.RUnlock()
+
+	if wantDay := time.Now().Format(rotatingDateFormat); gotDay != wantDay {
+		t.Fatalf("w.day after rollover = %s, want %s", gotDay, wantDay)
+	}
+	if freshFile == staleFile {
+		t.Fatalf("rollover did not open a new *os.File handle")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading current file: %v", err)
+	}
+	if string(data) != "line1\nline2\n" {
+		t.Fatalf("file content = %q, want %q (no bytes should be lost across rollover)", data, "line1\nline2\n")
+	}
+}
+
+// TestRotatingFileWriterCloseIsIdempotent 验证重复调用 Close 不会因为关闭
+// 已关闭的 stopCh 而 panic
+func TestRotatingFileWriterCloseIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(dir, "app", 0, false)
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("first Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close should be a no-op, got: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package log
+
+import (
+	"bufio"
+	"io"
+)
+
+// Sink 描述 Standard 分发日志的一个独立目的地。同一条记录会复制一份给每个
+// 注册的 Sink，各自按 MinLevel 过滤、按 Encoder 渲染（为空则退回 Standard
+// 当前的模板），用各自的 Colorized 决定要不要带颜色。典型用法是同一个
+// Standard 上同时挂一个不着色的 RotatingFileWriter（DEBUG+）和一个着色的
+// os.Stderr（WARN+），外加一个 JSON 编码的网络 sink（ERROR+）
+type Sink struct {
+	Writer    io.Writer
+	MinLevel  Level
+	Encoder   Encoder
+	Colorized bool
+}
+
+// sinkEntry 是 Sink 加上它自己的 bufio.Writer，只在 Standard 内部使用
+type sinkEntry struct {
+	Sink
+	out *bufio.Writer
+}
+
+func newSinkEntry(sk Sink) *sinkEntry {
+	return &sinkEntry{Sink: sk, out: bufio.NewWriter(sk.Writer)}
+}
+
+// NewNullSink 返回一个丢弃所有内容的 Sink，主要给测试用，避免真的写磁盘/网络
+func NewNullSink(minLevel Level) Sink {
+	return Sink{Writer: io.Discard, MinLevel: minLevel}
+}
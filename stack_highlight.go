@@ -0,0 +1,161 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StackTheme 为 StackLevel 还原出来的源码片段提供逐 token 的着色方案，
+// 默认配色参考了 delve 的 SourceListKeywordColor/SourceListStringColor/
+// SourceListNumberColor/SourceListCommentColor
+type StackTheme struct {
+	Keyword string // 关键字，比如 func、if、return
+	String  string // 字符串/字符字面量
+	Number  string // 数字字面量
+	Comment string // 注释
+	Ident   string // 标识符/函数名
+	Gutter  string // 行号
+	Reset   string
+}
+
+// DefaultStackTheme 返回内置的默认配色
+func DefaultStackTheme() StackTheme {
+	return StackTheme{
+		Keyword: "\033[34m",
+		String:  "\033[32m",
+		Number:  "\033[36m",
+		Comment: "\033[90m",
+		Ident:   "\033[33m",
+		Gutter:  "\033[90m",
+		Reset:   "\033[0m",
+	}
+}
+
+// stackFrameLoc 匹配 runtime.Stack 里形如 "\t/path/to/file.go:123 +0x1a" 的帧位置行
+var stackFrameLoc = regexp.MustCompile(`^\t(.+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+// sourceCache 避免同一份堆栈里命中同一个文件时反复读盘
+type sourceCache map[string][]string
+
+func (c sourceCache) lines(path string) []string {
+	if ls, ok := c[path]; ok {
+		return ls
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c[path] = nil
+		return nil
+	}
+	ls := strings.Split(string(data), "\n")
+	c[path] = ls
+	return ls
+}
+
+// highlightStack 把 runtime.Stack 的原始输出按帧拆开，在每个能定位到源文件的
+// 帧后面追加 contextLines 行上下文，并用 go/scanner 逐 token 着色。定位不到
+// 源文件（比如运行在别的机器上编译）的帧原样保留。
+func highlightStack(raw []byte, theme StackTheme, contextLines int) []byte {
+	cache := sourceCache{}
+	var out bytes.Buffer
+
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		out.WriteString(line)
+		out.WriteByte('\n')
+
+		m := stackFrameLoc.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+
+		src := cache.lines(m[1])
+		if src == nil {
+			continue
+		}
+
+		lo, hi := n-1-contextLines, n-1+contextLines
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(src)-1 {
+			hi = len(src) - 1
+		}
+
+		for i := lo; i <= hi; i++ {
+			fmt.Fprintf(&out, "\t%s%6d%s  %s\n", theme.Gutter, i+1, theme.Reset, highlightLine(src[i], theme))
+		}
+	}
+
+	return out.Bytes()
+}
+
+// highlightLine 用 go/scanner 给一行 Go 源码按 token 上色
+func highlightLine(line string, theme StackTheme) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(line))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(line), nil, scanner.ScanComments)
+
+	var out strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		offset := fset.Position(pos).Column - 1
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		if offset < last || offset+len(text) > len(line) {
+			continue
+		}
+
+		out.WriteString(line[last:offset])
+		if color := tokenColor(tok, theme); color != "" {
+			out.WriteString(color)
+			out.WriteString(text)
+			out.WriteString(theme.Reset)
+		} else {
+			out.WriteString(text)
+		}
+		last = offset + len(text)
+	}
+	out.WriteString(line[last:])
+
+	return out.String()
+}
+
+func tokenColor(tok token.Token, theme StackTheme) string {
+	switch {
+	case tok.IsKeyword():
+		return theme.Keyword
+	case tok == token.STRING, tok == token.CHAR:
+		return theme.String
+	case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
+		return theme.Number
+	case tok == token.COMMENT:
+		return theme.Comment
+	case tok == token.IDENT:
+		return theme.Ident
+	default:
+		return ""
+	}
+}
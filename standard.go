@@ -22,26 +22,41 @@ type record struct {
 	Line       int
 	Message    string
 	Stack      []byte
+	Fields     map[string]interface{}
 }
 
 // Standard 日志输出基本实现
 type Standard struct {
-	mu  sync.Mutex    // ensures atomic writes; protects the following fields
-	out *bufio.Writer // destination for output
+	mu sync.Mutex // ensures atomic writes; protects the following fields
 
-	format    string
-	pattern   string
-	colorized bool
+	sinks []*sinkEntry // sinks[0] 是默认 sink，由 SetWriter/Colorized/SetOutputFormat 驱动
+
+	format  string
+	pattern string
+
+	tplPlain *template.Template // 不带颜色的渲染模板
+	tplColor *template.Template // {{.Start}}pattern{{.End}} 包了一层颜色的渲染模板
 
-	tpl       *template.Template
 	prefixLen int
 	dateFmt   string
 	timeFmt   string
+
+	stackTheme        StackTheme
+	stackContextLines int // StackLevel 时每帧上下展示的源码行数
+
+	rawWriter    io.Writer // 默认 sink 未经 AnsiColorWriter 包装前的原始 writer
+	ansiDisabled bool      // Standard.DisableAnsiTranslation(true) 之后为 true
 }
 
 // NewStandard 返回标准实现
 func NewStandard(w io.Writer, format string) *Standard {
-	std := &Standard{out: bufio.NewWriter(w)}
+	std := &Standard{
+		stackTheme:        DefaultStackTheme(),
+		stackContextLines: 2,
+	}
+	std.sinks = []*sinkEntry{newSinkEntry(Sink{Writer: w})}
+	std.rawWriter = w
+	std.applyAnsiWrap()
 
 	// hack 如果用户不调用 SetFormat，直接用，那么也能找到主函数（main，实际是 init 函数）的所在的文件
 	std.prefixLen = -5
@@ -50,30 +65,50 @@ func NewStandard(w io.Writer, format string) *Standard {
 	return std
 }
 
-// SetWriter 改变输出流
+// SetWriter 改变默认 sink（sinks[0]）的输出流。在 Windows 下会自动尝试用
+// AnsiColorWriter 包一层，除非调用过 DisableAnsiTranslation(true)
 func (s *Standard) SetWriter(w io.Writer) {
 	s.mu.Lock()
-	s.out = bufio.NewWriter(w)
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+
+	s.rawWriter = w
+	s.applyAnsiWrap()
 }
 
-// Colorized 输出日志是否着色，默认着色
-func (s *Standard) Colorized(c bool) {
-	// 没改变
-	if c == s.colorized {
-		return
-	}
+// DisableAnsiTranslation 关闭（或重新开启）默认 sink 的 Windows ANSI 翻译。
+// 在非 Windows 平台上 AnsiColorWriter 本身就是透传，这个开关没有实际效果
+func (s *Standard) DisableAnsiTranslation(disabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	s.colorized = c
+	s.ansiDisabled = disabled
+	s.applyAnsiWrap()
+}
+
+// applyAnsiWrap 根据 ansiDisabled 决定默认 sink 是否经过 AnsiColorWriter，
+// 调用方必须已经持有 s.mu
+func (s *Standard) applyAnsiWrap() {
+	w := s.rawWriter
+	if !s.ansiDisabled {
+		w = NewAnsiColorWriter(w)
+	}
+	s.sinks[0].Writer = w
+	s.sinks[0].out = bufio.NewWriter(w)
+}
 
+// IsColorized 返回默认 sink 当前是否着色，供依赖 Standard 自行拼接转义序列的
+// 调用方（比如 middleware 子包）判断是否需要跳过着色
+func (s *Standard) IsColorized() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.sinks[0].Colorized
+}
 
-	p := s.pattern
-	if s.colorized {
-		p = "{{.Start}}" + p + "{{.End}}"
-	}
-	s.tpl = template.Must(template.New("record").Parse(p))
+// Colorized 默认 sink 输出日志是否着色，默认不着色
+func (s *Standard) Colorized(c bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks[0].Colorized = c
 }
 
 // SetFormat 改变日志格式
@@ -91,17 +126,73 @@ func (s *Standard) SetFormat(format string) {
 
 	s.dateFmt, s.timeFmt = ExtactDateTime(format)
 
-	p := parseFormat(format, s.prefixLen, s.dateFmt, s.timeFmt)
+	s.pattern = parseFormat(format, s.prefixLen, s.dateFmt, s.timeFmt)
+
+	s.tplPlain = template.Must(template.New("record").Parse(s.pattern))
+	s.tplColor = template.Must(template.New("record").Parse("{{.Start}}" + s.pattern + "{{.End}}"))
+}
+
+// SetStackTheme 覆盖 StackLevel 源码片段的配色方案
+func (s *Standard) SetStackTheme(t StackTheme) {
+	s.mu.Lock()
+	s.stackTheme = t
+	s.mu.Unlock()
+}
+
+// SetStackContextLines 设置 StackLevel 每一帧上下展示的源码行数，默认 2
+func (s *Standard) SetStackContextLines(n int) {
+	s.mu.Lock()
+	s.stackContextLines = n
+	s.mu.Unlock()
+}
 
-	s.pattern = p
-	if s.colorized {
-		p = "{{.Start}}" + p + "{{.End}}"
+// SetOutputFormat 切换默认 sink 的编码器，比如 NewJSONEncoder() 可以让每条
+// 日志变成一行 JSON，方便直接喂给 ELK/Loki 等系统；传 nil 恢复成默认的模板
+// 文本输出。其它 sink 的编码器通过各自的 Sink.Encoder 单独指定
+func (s *Standard) SetOutputFormat(enc Encoder) {
+	s.mu.Lock()
+	s.sinks[0].Encoder = enc
+	s.mu.Unlock()
+}
+
+// AddSink 注册一个额外的输出目的地：同一条日志会被分发给它，按 sink.MinLevel
+// 过滤（级别低于 MinLevel 的记录不会发给这个 sink），用 sink.Encoder 渲染
+// （为空则退回 Standard 当前的模板），sink.Colorized 控制这个 sink 自己是否
+// 带颜色 —— 和其它 sink、和默认 sink 的着色设置互不影响
+func (s *Standard) AddSink(sk Sink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks = append(s.sinks, newSinkEntry(sk))
+}
+
+// RemoveSink 按 Writer 找到并移除一个 AddSink 加进来的 sink，返回是否真的有
+// sink 被移除。sinks[0]（NewStandard/SetWriter 维护的默认 sink）不受影响——
+// Colorized/SetOutputFormat/DisableAnsiTranslation 这些方法都假定它总是存在
+func (s *Standard) RemoveSink(w io.Writer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 1; i < len(s.sinks); i++ {
+		if s.sinks[i].Writer == w {
+			s.sinks = append(s.sinks[:i], s.sinks[i+1:]...)
+			return true
+		}
 	}
-	s.tpl = template.Must(template.New("record").Parse(p))
+	return false
 }
 
 // Tprintf 打印日志
 func (s *Standard) Tprintf(v, l Level, tag string, format string, m ...interface{}) {
+	s.tprintf(v, l, tag, nil, format, m...)
+}
+
+// TprintfFields 和 Tprintf 一样，额外带上一组调用方自定义的字段。这些字段在
+// TextEncoder 下被忽略，在 JSONEncoder 下会被平铺进输出的 JSON 对象里
+func (s *Standard) TprintfFields(v, l Level, tag string, fields map[string]interface{}, format string, m ...interface{}) {
+	s.tprintf(v, l, tag, fields, format, m...)
+}
+
+func (s *Standard) tprintf(v, l Level, tag string, fields map[string]interface{}, format string, m ...interface{}) {
 	if v > l {
 		return
 	}
@@ -110,8 +201,9 @@ func (s *Standard) Tprintf(v, l Level, tag string, format string, m ...interface
 		tag = "-"
 	}
 	r := record{
-		Level: l.String(),
-		Tag:   tag,
+		Level:  l.String(),
+		Tag:    tag,
+		Fields: fields,
 	}
 
 	if s.dateFmt != "" {
@@ -124,7 +216,7 @@ func (s *Standard) Tprintf(v, l Level, tag string, format string, m ...interface
 
 	if s.prefixLen > -1 {
 		var ok bool
-		_, r.File, r.Line, ok = runtime.Caller(2) // expensive
+		_, r.File, r.Line, ok = runtime.Caller(3) // expensive
 		if ok && s.prefixLen < len(r.File) {
 			r.File = r.File[s.prefixLen:]
 		} else {
@@ -145,10 +237,6 @@ func (s *Standard) Tprintf(v, l Level, tag string, format string, m ...interface
 		r.Stack = r.Stack[:n]
 	}
 
-	if s.colorized {
-		r.Start, r.End = calculateColor(l)
-	}
-
 	s.mu.Lock()
 	defer func() {
 		s.mu.Unlock()
@@ -162,14 +250,50 @@ func (s *Standard) Tprintf(v, l Level, tag string, format string, m ...interface
 		}
 	}()
 
-	s.tpl.Execute(s.out, r)
-	s.out.WriteByte('\n')
+	// record 在这里只构建一次，每个 sink 按自己的 MinLevel/Encoder/Colorized
+	// 各写各的，互不影响
+	for _, sk := range s.sinks {
+		if l < sk.MinLevel {
+			continue
+		}
+		s.writeSink(sk, l, r)
+	}
+}
+
+// writeSink 把已经构建好的 record 写到某一个 sink，调用方必须已经持有 s.mu
+func (s *Standard) writeSink(sk *sinkEntry, l Level, r record) {
+	if sk.Colorized {
+		r.Start, r.End = calculateColor(l)
+	}
+
+	if sk.Encoder != nil {
+		b, err := sk.Encoder.Encode(&r)
+		if err != nil {
+			fmt.Fprintf(sk.out, "log: encode error: %v\n", err)
+			sk.out.Flush()
+			return
+		}
+		sk.out.Write(b)
+		sk.out.Flush()
+		return
+	}
+
+	tpl := s.tplPlain
+	if sk.Colorized {
+		tpl = s.tplColor
+	}
+	tpl.Execute(sk.out, r)
+	sk.out.WriteByte('\n')
 
 	if l == StackLevel {
-		s.out.Write(r.Stack)
+		if sk.Colorized {
+			sk.out.Write(highlightStack(r.Stack, s.stackTheme, s.stackContextLines))
+		} else {
+			sk.out.Write(r.Stack)
+		}
 	}
 
-	s.out.Flush()
+	sk.out.Flush()
 }
 
 // 格式解析，把格式串替换成 token 串
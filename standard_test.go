@@ -0,0 +1,79 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestStandardSinkFanOut 验证 AddSink 之后同一条记录会按每个 sink 自己的
+// MinLevel 过滤分发，NewNullSink 不会让别的 sink 少收到东西
+func TestStandardSinkFanOut(t *testing.T) {
+	std := NewStandard(io.Discard, "")
+	std.SetOutputFormat(NewJSONEncoder())
+
+	var warnBuf, errBuf bytes.Buffer
+	std.AddSink(Sink{Writer: &warnBuf, MinLevel: WarnLevel, Encoder: NewJSONEncoder()})
+	std.AddSink(Sink{Writer: &errBuf, MinLevel: ErrorLevel, Encoder: NewJSONEncoder()})
+	std.AddSink(NewNullSink(DebugLevel))
+
+	std.Tprintf(DebugLevel, DebugLevel, "t", "debug message")
+	std.Tprintf(WarnLevel, WarnLevel, "t", "warn message")
+	std.Tprintf(ErrorLevel, ErrorLevel, "t", "error message")
+
+	warnOut := warnBuf.String()
+	if !strings.Contains(warnOut, "warn message") || !strings.Contains(warnOut, "error message") {
+		t.Fatalf("warn sink missing warn/error records: %q", warnOut)
+	}
+	if strings.Contains(warnOut, "debug message") {
+		t.Fatalf("warn sink (MinLevel=WarnLevel) should not see debug records: %q", warnOut)
+	}
+
+	errOut := errBuf.String()
+	if !strings.Contains(errOut, "error message") {
+		t.Fatalf("error sink missing error record: %q", errOut)
+	}
+	if strings.Contains(errOut, "warn message") || strings.Contains(errOut, "debug message") {
+		t.Fatalf("error sink (MinLevel=ErrorLevel) should only see error records: %q", errOut)
+	}
+}
+
+// TestStandardTprintfFieldsFlattened 验证 TprintfFields 传入的字段被打平到
+// JSON 顶层，和 time/level/msg 平级，并且和保留字段撞名时会被改名而不是覆盖
+func TestStandardTprintfFieldsFlattened(t *testing.T) {
+	var buf bytes.Buffer
+	std := NewStandard(&buf, "")
+	std.SetOutputFormat(NewJSONEncoder())
+
+	std.TprintfFields(InfoLevel, InfoLevel, "t", map[string]interface{}{
+		"user_id": 42,
+		"msg":     "caller-supplied, should not clobber the real msg",
+	}, "hello %s", "world")
+
+	out := buf.String()
+	if !strings.Contains(out, `"user_id":42`) {
+		t.Fatalf("expected flattened user_id field, got %q", out)
+	}
+	if !strings.Contains(out, `"msg":"hello world"`) {
+		t.Fatalf("expected reserved msg field to stay the rendered message, got %q", out)
+	}
+	if !strings.Contains(out, `"fields.msg"`) {
+		t.Fatalf("expected colliding caller field to be renamed to fields.msg, got %q", out)
+	}
+}
+
+// TestRemoveSinkKeepsDefault 验证 RemoveSink 不会把默认 sink（sinks[0]）删掉，
+// 否则 Colorized/SetOutputFormat 等方法后续会在空切片上 panic
+func TestRemoveSinkKeepsDefault(t *testing.T) {
+	var buf bytes.Buffer
+	std := NewStandard(&buf, "")
+
+	if std.RemoveSink(&buf) {
+		t.Fatalf("RemoveSink should refuse to remove the default sink")
+	}
+
+	// 默认 sink 还在，这些调用不应该 panic
+	std.Colorized(true)
+	std.SetOutputFormat(nil)
+}